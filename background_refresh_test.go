@@ -0,0 +1,102 @@
+package auth0
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJitterReducesButNeverExceedsBase(t *testing.T) {
+	base := 100 * time.Millisecond
+	for i := 0; i < 50; i++ {
+		got := jitter(base)
+		assert.LessOrEqual(t, int64(got), int64(base))
+		assert.Greater(t, int64(got), int64(base)-int64(float64(base)*refreshJitterFraction)-1)
+	}
+}
+
+func TestJitterPassesThroughNonPositiveBase(t *testing.T) {
+	assert.Equal(t, time.Duration(0), jitter(0))
+	assert.Equal(t, -time.Second, jitter(-time.Second))
+}
+
+func TestBackgroundRefreshReloadsKeysOnASchedule(t *testing.T) {
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"keys":[{"kid":"key1","kty":"oct","k":"c2VjcmV0"}]}`))
+	}))
+	defer ts.Close()
+
+	client := NewJWKClient(JWKClientOptions{
+		URI:             ts.URL,
+		RefreshInterval: 10 * time.Millisecond,
+	}, nil)
+	defer client.Close()
+
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&requests) >= 3
+	}, time.Second, 5*time.Millisecond, "background refresh should have reloaded the JWKS more than once")
+}
+
+func TestCloseStopsBackgroundRefresh(t *testing.T) {
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"keys":[{"kid":"key1","kty":"oct","k":"c2VjcmV0"}]}`))
+	}))
+	defer ts.Close()
+
+	client := NewJWKClient(JWKClientOptions{
+		URI:             ts.URL,
+		RefreshInterval: 10 * time.Millisecond,
+	}, nil)
+
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&requests) >= 1
+	}, time.Second, 5*time.Millisecond, "background refresh should have fired at least once")
+
+	client.Close()
+	afterClose := atomic.LoadInt32(&requests)
+
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, afterClose, atomic.LoadInt32(&requests), "no further refresh should fire after Close")
+}
+
+func TestCloseStopsARefreshAlreadyInFlight(t *testing.T) {
+	var requests int32
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		<-release
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"keys":[{"kid":"key1","kty":"oct","k":"c2VjcmV0"}]}`))
+	}))
+	defer ts.Close()
+
+	client := NewJWKClient(JWKClientOptions{
+		URI:             ts.URL,
+		RefreshInterval: 10 * time.Millisecond,
+	}, nil)
+
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&requests) >= 1
+	}, time.Second, time.Millisecond, "background refresh should have started its first request")
+
+	// Close while backgroundRefresh is still blocked inside the download it
+	// started before Close ran. Once the download unblocks, backgroundRefresh
+	// must not reschedule a new timer.
+	client.Close()
+	close(release)
+
+	time.Sleep(50 * time.Millisecond)
+	afterClose := atomic.LoadInt32(&requests)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, afterClose, atomic.LoadInt32(&requests), "a refresh in flight when Close is called must not reschedule another one")
+}
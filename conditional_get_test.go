@@ -0,0 +1,88 @@
+package auth0
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDownloadKeysReusesLastKeysOn304(t *testing.T) {
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&requests, 1)
+		if n == 1 {
+			w.Header().Set("ETag", `"v1"`)
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"keys":[{"kid":"key1","kty":"oct","k":"c2VjcmV0"}]}`))
+			return
+		}
+
+		assert.Equal(t, `"v1"`, r.Header.Get("If-None-Match"))
+		w.WriteHeader(http.StatusNotModified)
+	}))
+	defer ts.Close()
+
+	client := NewJWKClient(JWKClientOptions{URI: ts.URL}, nil)
+	defer client.Close()
+
+	first, err := client.downloadKeys()
+	assert.NoError(t, err)
+	assert.Len(t, first, 1)
+
+	second, err := client.downloadKeys()
+	assert.NoError(t, err)
+	assert.Equal(t, first, second)
+	assert.EqualValues(t, 2, atomic.LoadInt32(&requests))
+}
+
+func TestDownloadKeysContextUsesConfiguredHTTPClient(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "test-agent", r.Header.Get("User-Agent"))
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"keys":[{"kid":"key1","kty":"oct","k":"c2VjcmV0"}]}`))
+	}))
+	defer ts.Close()
+
+	client := NewJWKClient(JWKClientOptions{
+		URI:        ts.URL,
+		HTTPClient: &http.Client{Transport: &userAgentRoundTripper{agent: "test-agent", next: http.DefaultTransport}},
+	}, nil)
+	defer client.Close()
+
+	keys, err := client.downloadKeys()
+	assert.NoError(t, err)
+	assert.Len(t, keys, 1)
+}
+
+type userAgentRoundTripper struct {
+	agent string
+	next  http.RoundTripper
+}
+
+func (rt *userAgentRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	req.Header.Set("User-Agent", rt.agent)
+	return rt.next.RoundTrip(req)
+}
+
+func TestDownloadKeysContextRespectsCanceledContext(t *testing.T) {
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer ts.Close()
+	defer close(release)
+
+	client := NewJWKClient(JWKClientOptions{URI: ts.URL}, nil)
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err := client.downloadKeysContext(ctx)
+	assert.Error(t, err)
+}
@@ -0,0 +1,129 @@
+package auth0
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"gopkg.in/square/go-jose.v2"
+)
+
+// FileKeyCacher is a KeyCacher that persists the JWKS to a JSON file on
+// disk, alongside an in-memory cache, so that a process restart starts warm
+// instead of having to re-download every kid it sees from the IdP.
+type FileKeyCacher struct {
+	path string
+	// persistMu serializes Add calls end-to-end (mutate + snapshot + write +
+	// rename), so that concurrent Adds for different kids can't race their
+	// file writes and silently drop an entry from disk.
+	persistMu sync.Mutex
+	memory    *memoryKeyCacher
+}
+
+type fileKeyCacherEntry struct {
+	AddedAt time.Time       `json:"added_at"`
+	Key     jose.JSONWebKey `json:"key"`
+}
+
+// NewFileKeyCacher creates a FileKeyCacher backed by path, loading any keys
+// already persisted there. maxAge and maxSize behave as for
+// NewMemoryKeyCacher.
+func NewFileKeyCacher(path string, maxAge time.Duration, maxSize int) (*FileKeyCacher, error) {
+	fkc := &FileKeyCacher{
+		path: path,
+		memory: &memoryKeyCacher{
+			entries: make(map[string]keyCacherEntry),
+			maxAge:  maxAge,
+			maxSize: maxSize,
+		},
+	}
+
+	if err := fkc.load(); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	return fkc, nil
+}
+
+func (fkc *FileKeyCacher) load() error {
+	data, err := os.ReadFile(fkc.path)
+	if err != nil {
+		return err
+	}
+
+	if len(data) == 0 {
+		return nil
+	}
+
+	var entries map[string]fileKeyCacherEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return err
+	}
+
+	fkc.memory.mu.Lock()
+	defer fkc.memory.mu.Unlock()
+	for keyID, entry := range entries {
+		fkc.memory.entries[keyID] = keyCacherEntry{entry.AddedAt, entry.Key}
+	}
+
+	return nil
+}
+
+// Get implements KeyCacher.
+func (fkc *FileKeyCacher) Get(keyID string) (*jose.JSONWebKey, error) {
+	return fkc.memory.Get(keyID)
+}
+
+// Add implements KeyCacher, persisting the resulting cache contents to disk.
+func (fkc *FileKeyCacher) Add(keyID string, downloadedKeys []jose.JSONWebKey) (*jose.JSONWebKey, error) {
+	fkc.persistMu.Lock()
+	defer fkc.persistMu.Unlock()
+
+	addedKey, err := fkc.memory.Add(keyID, downloadedKeys)
+	if addedKey == nil {
+		return addedKey, err
+	}
+
+	if persistErr := fkc.persist(); persistErr != nil {
+		return addedKey, persistErr
+	}
+
+	return addedKey, err
+}
+
+// persist atomically writes the current cache contents to fkc.path, so a
+// reader never observes a partially written file.
+func (fkc *FileKeyCacher) persist() error {
+	fkc.memory.mu.Lock()
+	entries := make(map[string]fileKeyCacherEntry, len(fkc.memory.entries))
+	for keyID, entry := range fkc.memory.entries {
+		entries[keyID] = fileKeyCacherEntry{entry.addedAt, entry.JSONWebKey}
+	}
+	fkc.memory.mu.Unlock()
+
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(fkc.path)
+	tmp, err := os.CreateTemp(dir, filepath.Base(fkc.path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	return os.Rename(tmpPath, fkc.path)
+}
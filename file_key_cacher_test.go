@@ -0,0 +1,90 @@
+package auth0
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+)
+
+func TestFileKeyCacherPersistsAcrossInstances(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	downloadedKeys := []jose.JSONWebKey{{KeyID: "test1", Key: []byte("secret1")}}
+
+	first, err := NewFileKeyCacher(path, MaxAgeNoCheck, -1)
+	assert.NoError(t, err)
+
+	_, err = first.Add("test1", downloadedKeys)
+	assert.NoError(t, err)
+
+	second, err := NewFileKeyCacher(path, MaxAgeNoCheck, -1)
+	assert.NoError(t, err)
+
+	key, err := second.Get("test1")
+	assert.NoError(t, err)
+	assert.Equal(t, "test1", key.KeyID)
+}
+
+func TestFileKeyCacherTTL(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	downloadedKeys := []jose.JSONWebKey{{KeyID: "test1", Key: []byte("secret1")}}
+
+	fkc, err := NewFileKeyCacher(path, time.Duration(0), -1)
+	assert.NoError(t, err)
+
+	_, err = fkc.Add("test1", downloadedKeys)
+	assert.NoError(t, err)
+
+	_, err = fkc.Get("test1")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "key exists but is expired")
+}
+
+func TestFileKeyCacherEviction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	downloadedKeys := []jose.JSONWebKey{{KeyID: "test1", Key: []byte("secret1")}, {KeyID: "test2", Key: []byte("secret2")}}
+
+	fkc, err := NewFileKeyCacher(path, MaxAgeNoCheck, 1)
+	assert.NoError(t, err)
+
+	_, err = fkc.Add("test1", downloadedKeys)
+	assert.NoError(t, err)
+	_, err = fkc.Add("test2", downloadedKeys)
+	assert.NoError(t, err)
+
+	_, err = fkc.Get("test1")
+	assert.Error(t, err)
+
+	key, err := fkc.Get("test2")
+	assert.NoError(t, err)
+	assert.Equal(t, "test2", key.KeyID)
+}
+
+func TestFileKeyCacherConcurrentAddGet(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	downloadedKeys := []jose.JSONWebKey{{KeyID: "test1", Key: []byte("secret1")}}
+
+	fkc, err := NewFileKeyCacher(path, MaxAgeNoCheck, -1)
+	assert.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, _ = fkc.Add("test1", downloadedKeys)
+		}()
+		go func() {
+			defer wg.Done()
+			_, _ = fkc.Get("test1")
+		}()
+	}
+	wg.Wait()
+
+	key, err := fkc.Get("test1")
+	assert.NoError(t, err)
+	assert.Equal(t, "test1", key.KeyID)
+}
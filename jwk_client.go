@@ -1,22 +1,96 @@
 package auth0
 
 import (
+	"context"
 	"encoding/json"
+	"math/rand"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/go-errors/errors"
+	"golang.org/x/sync/singleflight"
 	"gopkg.in/square/go-jose.v2"
 )
 
 var (
-	ErrInvalidContentType = errors.New("should have a JSON content type for JWKS endpoint")
-	ErrInvalidAlgorithm   = errors.New("algorithm is invalid")
+	ErrInvalidContentType  = errors.New("should have a JSON content type for JWKS endpoint")
+	ErrInvalidAlgorithm    = errors.New("algorithm is invalid")
+	ErrRefreshRateLimited  = errors.New("JWKS refresh rate limit exceeded")
+	ErrDisallowedAlgorithm = errors.New("algorithm is not in the list of allowed algorithms")
+	ErrKeyUseMismatch      = errors.New("key use does not permit signature verification")
 )
 
+// refreshJitterFraction is the maximum fraction of the observed key age that
+// is randomly shaved off a scheduled background refresh, so that replicas
+// sharing a JWKS endpoint don't all refresh in lockstep.
+const refreshJitterFraction = 0.10
+
 type JWKClientOptions struct {
 	URI string
+
+	// UseDynamicJWKSCacheTTL, when true, makes downloadKeys honor the
+	// Cache-Control: max-age (or, failing that, Expires) header returned by
+	// the JWKS endpoint as the cache TTL for newly downloaded keys,
+	// overriding the KeyCacher's statically configured max age. The cache
+	// TTL override only takes effect if the configured KeyCacher implements
+	// DynamicTTLKeyCacher; independently of the KeyCacher, the observed TTL
+	// also replaces RefreshInterval as the delay between background
+	// refreshes.
+	UseDynamicJWKSCacheTTL bool
+
+	// RefreshInterval, when set to a positive value, makes the JWKClient
+	// proactively reload the JWKS in the background ahead of expiry, so
+	// that GetKey/GetSecret are served from cache instead of blocking
+	// request handling on a live download.
+	RefreshInterval time.Duration
+
+	// RefreshOnFailureInterval is the delay before retrying a failed
+	// background refresh. Defaults to RefreshInterval / 4 when unset.
+	RefreshOnFailureInterval time.Duration
+
+	// Hooks, if set, is notified of cache hits/misses and refresh errors so
+	// operators can monitor JWKS refresh behavior.
+	Hooks JWKClientHooks
+
+	// UnknownKidTTL configures how long a kid that was absent from the last
+	// JWKS download is remembered as unknown, skipping a re-download on
+	// subsequent lookups until it expires. Only takes effect if the
+	// configured KeyCacher implements NegativeCacher. Zero disables
+	// negative caching.
+	UnknownKidTTL time.Duration
+
+	// RefreshMinInterval caps how often a JWKS re-download may be triggered
+	// by kid-miss lookups, so a flood of tokens with unknown kids can't
+	// each fire an outbound request. Zero disables rate limiting.
+	RefreshMinInterval time.Duration
+
+	// RefreshBurst is the number of kid-miss downloads allowed to proceed
+	// back-to-back before RefreshMinInterval gating kicks in, e.g. so a
+	// handful of distinct unknown kids arriving in the same instant don't
+	// unnecessarily serialize behind one another. Defaults to 1 (no burst)
+	// when unset.
+	RefreshBurst int
+
+	// HTTPClient is used to download the JWKS. Defaults to
+	// http.DefaultClient when nil.
+	HTTPClient *http.Client
+
+	// AllowedAlgorithms restricts which JWT "alg" header values GetSecret
+	// will accept, e.g. []string{"RS256", "ES384"}. The resolved key's own
+	// Algorithm, when present, must also match. Leave empty to accept any
+	// algorithm the resolved key supports (not recommended).
+	AllowedAlgorithms []string
+}
+
+// JWKClientHooks lets operators observe a JWKClient's key cache and refresh
+// behavior, e.g. to export metrics.
+type JWKClientHooks interface {
+	OnCacheHit(keyID string)
+	OnCacheMiss(keyID string)
+	OnRefreshError(keyID string, err error)
 }
 
 type JWKS struct {
@@ -25,9 +99,42 @@ type JWKS struct {
 
 type JWKClient struct {
 	keyCacher KeyCacher
-	mu        sync.Mutex
+	optionsMu sync.RWMutex
 	options   JWKClientOptions
 	extractor RequestTokenExtractor
+	discovery *oidcDiscovery
+
+	// refreshTimerMu guards refreshTimer and closed, since both are read and
+	// written from whichever goroutine calls Close as well as from the
+	// timer's own goroutine as backgroundRefresh reschedules itself.
+	refreshTimerMu sync.Mutex
+	refreshTimer   *time.Timer
+	closed         bool
+
+	// sf ensures that concurrent lookups for the same unknown kid share a
+	// single JWKS download instead of each firing their own request; lookups
+	// for different kids proceed independently.
+	sf singleflight.Group
+
+	// refreshLimiter caps how often kid-miss lookups may trigger a fresh
+	// JWKS download. See JWKClientOptions.RefreshMinInterval.
+	refreshLimiter *rateLimiter
+
+	// validatorMu guards the conditional-GET validators and the JWKS they
+	// were last observed with, so a 304 response can be resolved back to
+	// the keys it confirms are still current.
+	validatorMu  sync.Mutex
+	etag         string
+	lastModified string
+	lastKeys     []jose.JSONWebKey
+
+	// lastObservedTTLMu guards lastObservedTTL, the most recent Cache-Control
+	// or Expires derived TTL seen from the JWKS endpoint. backgroundRefresh
+	// uses it, when UseDynamicJWKSCacheTTL is set, to schedule the next
+	// proactive refresh around what the endpoint actually advertises instead
+	// of only the statically configured RefreshInterval.
+	lastObservedTTLMu sync.Mutex
+	lastObservedTTL   time.Duration
 }
 
 // NewJWKClient creates a new JWKClient instance from the
@@ -39,12 +146,16 @@ func NewJWKClient(options JWKClientOptions, extractor RequestTokenExtractor) *JW
 
 	keyCacher := newMemoryPersistentKeyCacher()
 
-	return &JWKClient{
-		keyCacher,
-		sync.Mutex{},
-		options,
-		extractor,
+	client := &JWKClient{
+		keyCacher:      keyCacher,
+		options:        options,
+		extractor:      extractor,
+		refreshLimiter: newRateLimiterWithBurst(options.RefreshMinInterval, options.RefreshBurst),
 	}
+	client.applyUnknownKidTTL()
+	client.startBackgroundRefresh()
+
+	return client
 }
 
 func NewJWKClientWithCustomCacher(options JWKClientOptions, extractor RequestTokenExtractor, keyCacher KeyCacher) *JWKClient {
@@ -55,47 +166,272 @@ func NewJWKClientWithCustomCacher(options JWKClientOptions, extractor RequestTok
 		keyCacher = newMemoryPersistentKeyCacher()
 	}
 
-	return &JWKClient{
-		keyCacher,
-		sync.Mutex{},
-		options,
-		extractor,
+	client := &JWKClient{
+		keyCacher:      keyCacher,
+		options:        options,
+		extractor:      extractor,
+		refreshLimiter: newRateLimiterWithBurst(options.RefreshMinInterval, options.RefreshBurst),
+	}
+	client.applyUnknownKidTTL()
+	client.startBackgroundRefresh()
+
+	return client
+}
+
+// applyUnknownKidTTL propagates JWKClientOptions.UnknownKidTTL to the
+// configured KeyCacher, if it supports negative caching.
+func (j *JWKClient) applyUnknownKidTTL() {
+	if j.options.UnknownKidTTL <= 0 {
+		return
+	}
+	if negativeCacher, ok := j.keyCacher.(NegativeCacher); ok {
+		negativeCacher.SetUnknownKidTTL(j.options.UnknownKidTTL)
+	}
+}
+
+// Close stops the background refresh loop started for a positive
+// RefreshInterval, including a refresh that is already in flight and would
+// otherwise reschedule itself. It is a no-op for a client that never had one
+// running. Callers that configure RefreshInterval are responsible for
+// calling Close once the client is no longer needed, so its timer doesn't
+// keep firing after the client would otherwise be garbage collected.
+func (j *JWKClient) Close() {
+	j.refreshTimerMu.Lock()
+	defer j.refreshTimerMu.Unlock()
+
+	j.closed = true
+	if j.refreshTimer != nil {
+		j.refreshTimer.Stop()
+	}
+}
+
+// startBackgroundRefresh schedules the first proactive key refresh if the
+// client was configured with a RefreshInterval.
+func (j *JWKClient) startBackgroundRefresh() {
+	if j.options.RefreshInterval <= 0 {
+		return
+	}
+	j.scheduleRefresh(j.options.RefreshInterval)
+}
+
+func (j *JWKClient) scheduleRefresh(after time.Duration) {
+	if after <= 0 {
+		return
+	}
+
+	j.refreshTimerMu.Lock()
+	defer j.refreshTimerMu.Unlock()
+
+	if j.closed {
+		return
 	}
+	j.refreshTimer = time.AfterFunc(jitter(after), j.backgroundRefresh)
 }
 
-// GetKey returns the key associated with the provided ID.
+// backgroundRefresh reloads the JWKS ahead of expiry so that concurrent
+// request handlers never block on network I/O for already-known keys. On
+// failure it backs off to RefreshOnFailureInterval instead of dropping the
+// keys it already has cached.
+func (j *JWKClient) backgroundRefresh() {
+	keys, err := j.downloadKeys()
+	if err != nil {
+		onFailure := j.options.RefreshOnFailureInterval
+		if onFailure <= 0 {
+			onFailure = j.options.RefreshInterval / 4
+		}
+		j.scheduleRefresh(onFailure)
+		return
+	}
+
+	for _, key := range keys {
+		j.keyCacher.Add(key.KeyID, keys)
+	}
+
+	j.scheduleRefresh(j.nextRefreshInterval())
+}
+
+// nextRefreshInterval returns how long to wait before the next proactive
+// refresh. When UseDynamicJWKSCacheTTL is set and the most recent download
+// advertised a Cache-Control/Expires derived TTL, that TTL takes precedence
+// over the statically configured RefreshInterval, so the background loop
+// tracks the endpoint's own notion of freshness instead of a fixed interval
+// that may outlive or undercut it.
+func (j *JWKClient) nextRefreshInterval() time.Duration {
+	if j.options.UseDynamicJWKSCacheTTL {
+		j.lastObservedTTLMu.Lock()
+		ttl := j.lastObservedTTL
+		j.lastObservedTTLMu.Unlock()
+		if ttl > 0 {
+			return ttl
+		}
+	}
+	return j.options.RefreshInterval
+}
+
+// jitter returns base reduced by a random amount up to refreshJitterFraction
+// of itself, so the next reload happens a little before the key would
+// actually expire.
+func jitter(base time.Duration) time.Duration {
+	if base <= 0 {
+		return base
+	}
+	maxDelta := int64(float64(base) * refreshJitterFraction)
+	if maxDelta <= 0 {
+		return base
+	}
+	return base - time.Duration(rand.Int63n(maxDelta+1))
+}
+
+// GetKey returns the key associated with the provided ID. Cache reads never
+// block on a download: the first caller to miss the cache for a given kid
+// downloads the JWKS, and concurrent callers for the same kid wait on that
+// single download via j.sf instead of each issuing their own request.
 func (j *JWKClient) GetKey(ID string) (jose.JSONWebKey, error) {
-	j.mu.Lock()
-	defer j.mu.Unlock()
-
-	searchedKey, err := j.keyCacher.Get(ID)
-
-	if searchedKey == nil {
-		if keys, err := j.downloadKeys(); err != nil {
-			return jose.JSONWebKey{}, err
-		} else {
-			addedKey, err := j.keyCacher.Add(ID, keys)
-			if addedKey == nil {
-				return jose.JSONWebKey{}, err
+	return j.GetKeyContext(context.Background(), ID)
+}
+
+// GetKeyContext is GetKey with a context that is threaded through to the
+// underlying JWKS download, so callers can enforce their own deadlines and
+// cancellation on an inbound request instead of inheriting none.
+func (j *JWKClient) GetKeyContext(ctx context.Context, ID string) (jose.JSONWebKey, error) {
+	if searchedKey, err := j.keyCacher.Get(ID); searchedKey != nil {
+		j.notifyCacheHit(ID)
+		return *searchedKey, err
+	}
+
+	j.notifyCacheMiss(ID)
+
+	// Use DoChan instead of Do so that a caller whose context is canceled or
+	// times out can stop waiting on its own terms, even though the download
+	// it was waiting on is shared with, and keeps running for, other
+	// callers. The first caller in still drives refreshKey with its own
+	// context; followers only ever observe ctx.Done() against theirs.
+	resultCh := j.sf.DoChan(ID, func() (interface{}, error) {
+		return j.refreshKey(ctx, ID)
+	})
+
+	select {
+	case res := <-resultCh:
+		if res.Err != nil {
+			j.notifyRefreshError(ID, res.Err)
+			return jose.JSONWebKey{}, res.Err
+		}
+		return res.Val.(jose.JSONWebKey), nil
+	case <-ctx.Done():
+		return jose.JSONWebKey{}, ctx.Err()
+	}
+}
+
+// refreshKey downloads the JWKS and caches the key matching ID. It is only
+// ever run once per kid at a time, via j.sf.
+func (j *JWKClient) refreshKey(ctx context.Context, ID string) (jose.JSONWebKey, error) {
+	// Another singleflight caller for this kid may have populated the cache
+	// while we were waiting to run.
+	if searchedKey, _ := j.keyCacher.Get(ID); searchedKey != nil {
+		return *searchedKey, nil
+	}
+
+	negativeCacher, hasNegativeCache := j.keyCacher.(NegativeCacher)
+	if hasNegativeCache && negativeCacher.IsNegativelyCached(ID) {
+		return jose.JSONWebKey{}, ErrNoKeyFound
+	}
+
+	if j.refreshLimiter != nil && !j.refreshLimiter.Allow() {
+		return jose.JSONWebKey{}, ErrRefreshRateLimited
+	}
+
+	keys, err := j.downloadKeysContext(ctx)
+	if err != nil {
+		return jose.JSONWebKey{}, err
+	}
+
+	addedKey, err := j.keyCacher.Add(ID, keys)
+	if addedKey == nil && err == ErrNoKeyFound && j.discovery != nil {
+		if rediscoverErr := j.discovery.refresh(j); rediscoverErr == nil {
+			if keys, downloadErr := j.downloadKeysContext(ctx); downloadErr == nil {
+				addedKey, err = j.keyCacher.Add(ID, keys)
 			}
-			return *addedKey, err
 		}
 	}
-	return *searchedKey, err
+	if addedKey == nil {
+		if hasNegativeCache && err == ErrNoKeyFound {
+			negativeCacher.AddNegative(ID)
+		}
+		return jose.JSONWebKey{}, err
+	}
+
+	return *addedKey, nil
+}
+
+func (j *JWKClient) notifyCacheHit(keyID string) {
+	if j.options.Hooks != nil {
+		j.options.Hooks.OnCacheHit(keyID)
+	}
+}
+
+func (j *JWKClient) notifyCacheMiss(keyID string) {
+	if j.options.Hooks != nil {
+		j.options.Hooks.OnCacheMiss(keyID)
+	}
+}
+
+func (j *JWKClient) notifyRefreshError(keyID string, err error) {
+	if j.options.Hooks != nil {
+		j.options.Hooks.OnRefreshError(keyID, err)
+	}
 }
 
 func (j *JWKClient) downloadKeys() ([]jose.JSONWebKey, error) {
-	resp, err := http.Get(j.options.URI)
+	return j.downloadKeysContext(context.Background())
+}
+
+// downloadKeysContext downloads the JWKS, honoring ctx for cancellation and
+// deadlines. It sends If-None-Match/If-Modified-Since validators from the
+// previous response, if any, and on a 304 response returns the keys that
+// validator was last seen with instead of re-downloading them.
+func (j *JWKClient) downloadKeysContext(ctx context.Context) ([]jose.JSONWebKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.jwksURI(), nil)
+	if err != nil {
+		return []jose.JSONWebKey{}, err
+	}
+
+	j.validatorMu.Lock()
+	if j.etag != "" {
+		req.Header.Set("If-None-Match", j.etag)
+	}
+	if j.lastModified != "" {
+		req.Header.Set("If-Modified-Since", j.lastModified)
+	}
+	j.validatorMu.Unlock()
 
+	resp, err := j.httpClient().Do(req)
 	if err != nil {
 		return []jose.JSONWebKey{}, err
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotModified {
+		j.validatorMu.Lock()
+		cached := j.lastKeys
+		j.validatorMu.Unlock()
+		return cached, nil
+	}
+
 	if contentH := resp.Header.Get("Content-Type"); !strings.HasPrefix(contentH, "application/json") {
 		return []jose.JSONWebKey{}, ErrInvalidContentType
 	}
 
+	if j.options.UseDynamicJWKSCacheTTL {
+		if ttl, ok := parseCacheMaxAge(resp.Header); ok {
+			if dynamicCacher, ok := j.keyCacher.(DynamicTTLKeyCacher); ok {
+				dynamicCacher.SetNextTTL(ttl)
+			}
+			j.lastObservedTTLMu.Lock()
+			j.lastObservedTTL = ttl
+			j.lastObservedTTLMu.Unlock()
+		}
+	}
+
 	var jwks = JWKS{}
 	err = json.NewDecoder(resp.Body).Decode(&jwks)
 
@@ -107,11 +443,40 @@ func (j *JWKClient) downloadKeys() ([]jose.JSONWebKey, error) {
 		return []jose.JSONWebKey{}, ErrNoKeyFound
 	}
 
+	j.validatorMu.Lock()
+	j.etag = resp.Header.Get("ETag")
+	j.lastModified = resp.Header.Get("Last-Modified")
+	j.lastKeys = jwks.Keys
+	j.validatorMu.Unlock()
+
 	return jwks.Keys, nil
 }
 
+// httpClient returns the configured HTTPClient, falling back to
+// http.DefaultClient.
+func (j *JWKClient) httpClient() *http.Client {
+	if j.options.HTTPClient != nil {
+		return j.options.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+// jwksURI returns the currently configured JWKS URI, safe for concurrent use
+// with oidcDiscovery.refresh updating it after a re-discovery.
+func (j *JWKClient) jwksURI() string {
+	j.optionsMu.RLock()
+	defer j.optionsMu.RUnlock()
+	return j.options.URI
+}
+
 // GetSecret implements the GetSecret method of the SecretProvider interface.
 func (j *JWKClient) GetSecret(r *http.Request) (interface{}, error) {
+	return j.GetSecretContext(context.Background(), r)
+}
+
+// GetSecretContext is GetSecret with a context that is threaded through to
+// the underlying JWKS download.
+func (j *JWKClient) GetSecretContext(ctx context.Context, r *http.Request) (interface{}, error) {
 	token, err := j.extractor.Extract(r)
 	if err != nil {
 		return nil, err
@@ -123,5 +488,73 @@ func (j *JWKClient) GetSecret(r *http.Request) (interface{}, error) {
 
 	header := token.Headers[0]
 
-	return j.GetKey(header.KeyID)
+	if !j.algorithmAllowed(header.Algorithm) {
+		return nil, ErrDisallowedAlgorithm
+	}
+
+	key, err := j.GetKeyContext(ctx, header.KeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if key.Algorithm != "" && !j.algorithmAllowed(key.Algorithm) {
+		return nil, ErrDisallowedAlgorithm
+	}
+
+	// The allow-list check above only constrains header.Algorithm and
+	// key.Algorithm independently, which doesn't stop a token whose header
+	// claims one allowed alg from being verified against a key registered
+	// under a different allowed alg (e.g. header RS256 against an ES384
+	// key) if an attacker can influence which key a kid resolves to. Tie
+	// the two together explicitly.
+	if key.Algorithm != "" && header.Algorithm != key.Algorithm {
+		return nil, ErrDisallowedAlgorithm
+	}
+
+	if key.Use != "" && key.Use != "sig" {
+		return nil, ErrKeyUseMismatch
+	}
+
+	return key, nil
+}
+
+// algorithmAllowed reports whether alg is permitted by
+// JWKClientOptions.AllowedAlgorithms. An empty allow-list permits anything.
+func (j *JWKClient) algorithmAllowed(alg string) bool {
+	if len(j.options.AllowedAlgorithms) == 0 {
+		return true
+	}
+	for _, allowed := range j.options.AllowedAlgorithms {
+		if allowed == alg {
+			return true
+		}
+	}
+	return false
+}
+
+// parseCacheMaxAge extracts a cache TTL from a JWKS response's Cache-Control
+// max-age directive, falling back to the Expires header when max-age is
+// absent. It reports false when neither header yields a usable TTL.
+func parseCacheMaxAge(header http.Header) (time.Duration, bool) {
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		directive = strings.TrimSpace(directive)
+		if !strings.HasPrefix(directive, "max-age=") {
+			continue
+		}
+		seconds, err := strconv.Atoi(strings.TrimPrefix(directive, "max-age="))
+		if err != nil || seconds <= 0 {
+			continue
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if expires := header.Get("Expires"); expires != "" {
+		if t, err := http.ParseTime(expires); err == nil {
+			if ttl := time.Until(t); ttl > 0 {
+				return ttl, true
+			}
+		}
+	}
+
+	return 0, false
 }
@@ -1,6 +1,7 @@
 package auth0
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
@@ -193,6 +194,140 @@ func TestGetKeyOfJWKClient(t *testing.T) {
 	}
 }
 
+func TestAlgorithmAllowed(t *testing.T) {
+	tests := []struct {
+		name     string
+		allowed  []string
+		alg      string
+		expected bool
+	}{
+		{
+			name:     "empty allow-list permits anything",
+			allowed:  nil,
+			alg:      "RS256",
+			expected: true,
+		},
+		{
+			name:     "alg in allow-list",
+			allowed:  []string{"RS256", "ES384"},
+			alg:      "ES384",
+			expected: true,
+		},
+		{
+			name:     "alg not in allow-list",
+			allowed:  []string{"RS256"},
+			alg:      "HS256",
+			expected: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := &JWKClient{options: JWKClientOptions{AllowedAlgorithms: test.allowed}}
+			assert.Equal(t, test.expected, client.algorithmAllowed(test.alg))
+		})
+	}
+}
+
+func TestNextRefreshIntervalPrefersObservedDynamicTTL(t *testing.T) {
+	tests := []struct {
+		name            string
+		useDynamicTTL   bool
+		refreshInterval time.Duration
+		observedTTL     time.Duration
+		expected        time.Duration
+	}{
+		{
+			name:            "dynamic TTL disabled falls back to RefreshInterval",
+			useDynamicTTL:   false,
+			refreshInterval: time.Hour,
+			observedTTL:     5 * time.Minute,
+			expected:        time.Hour,
+		},
+		{
+			name:            "dynamic TTL enabled but nothing observed yet falls back to RefreshInterval",
+			useDynamicTTL:   true,
+			refreshInterval: time.Hour,
+			observedTTL:     0,
+			expected:        time.Hour,
+		},
+		{
+			name:            "dynamic TTL enabled and observed takes precedence",
+			useDynamicTTL:   true,
+			refreshInterval: time.Hour,
+			observedTTL:     5 * time.Minute,
+			expected:        5 * time.Minute,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := &JWKClient{
+				options: JWKClientOptions{
+					UseDynamicJWKSCacheTTL: test.useDynamicTTL,
+					RefreshInterval:        test.refreshInterval,
+				},
+				lastObservedTTL: test.observedTTL,
+			}
+			assert.Equal(t, test.expected, client.nextRefreshInterval())
+		})
+	}
+}
+
+func TestGetKeyContextHonorsCallerCancellationDuringDownload(t *testing.T) {
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintln(w, `{"keys":[]}`)
+	}))
+	defer ts.Close()
+	defer close(release)
+
+	client := NewJWKClient(JWKClientOptions{URI: ts.URL}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := client.GetKeyContext(ctx, "key1")
+		done <- err
+	}()
+
+	cancel()
+
+	select {
+	case err := <-done:
+		assert.Equal(t, context.Canceled, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("GetKeyContext did not return promptly after its context was canceled")
+	}
+}
+
+func TestGetSecretRejectsHeaderKeyAlgorithmMismatch(t *testing.T) {
+	opts, _, _, err := genNewTestServer(true)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	opts.AllowedAlgorithms = []string{string(jose.RS256), string(jose.ES384)}
+
+	client := NewJWKClient(opts, nil)
+
+	// "keyRS256" is served by genNewTestServer as an RS256 key, which is also
+	// individually on the allow-list. Forging a header that claims ES384
+	// while pointing at "keyRS256" must still be rejected: the header's alg
+	// has to match the alg of the key it actually resolves to, not merely
+	// appear on the allow-list.
+	token := getTestTokenWithKid(defaultAudience, defaultIssuer, time.Now().Add(24*time.Hour), jose.ES384, genECDSAJWK(jose.ES384, "keyRS256"), "keyRS256")
+
+	req, _ := http.NewRequest("", "http://localhost", nil)
+	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token))
+
+	_, err = client.GetSecret(req)
+	assert.Equal(t, ErrDisallowedAlgorithm, err)
+}
+
 func TestCreateJWKClientCustomCacher(t *testing.T) {
 	opts, _, _, err := genNewTestServer(true)
 	if err != nil {
@@ -0,0 +1,255 @@
+package auth0
+
+import (
+	"sync"
+	"time"
+
+	"github.com/go-errors/errors"
+	"gopkg.in/square/go-jose.v2"
+)
+
+// MaxAgeNoCheck can be used in place of a maxAge when creating a KeyCacher to
+// indicate that cached keys should never expire on their own.
+const MaxAgeNoCheck = -1 * time.Second
+
+// ErrNoKeyFound is returned by a KeyCacher when the requested key is neither
+// held in the cache nor present amongst the keys it was asked to store.
+var ErrNoKeyFound = errors.New("no Keys has been found")
+
+// KeyCacher is used to cache keys so that the JWKS endpoint doesn't need to
+// be queried for every incoming request.
+type KeyCacher interface {
+	Get(keyID string) (*jose.JSONWebKey, error)
+	Add(keyID string, downloadedKeys []jose.JSONWebKey) (*jose.JSONWebKey, error)
+}
+
+// DynamicTTLKeyCacher is implemented by KeyCacher implementations that can
+// have the max age used for newly added entries overridden on a per-download
+// basis, e.g. by a Cache-Control or Expires header returned by the JWKS
+// endpoint, instead of always falling back to their statically configured
+// max age.
+type DynamicTTLKeyCacher interface {
+	KeyCacher
+
+	// SetNextTTL overrides the max age applied to entries added from this
+	// point on, until it is called again. A ttl <= 0 reverts to the
+	// cacher's statically configured max age.
+	SetNextTTL(ttl time.Duration)
+}
+
+// NegativeCacher is implemented by KeyCacher implementations that can
+// remember, for a short TTL, that a kid was absent from the last JWKS
+// download. JWKClient consults it before re-downloading the JWKS for a kid
+// it has already failed to find, so a flood of tokens carrying unknown or
+// garbage kids can't each trigger an outbound request.
+type NegativeCacher interface {
+	KeyCacher
+
+	// IsNegativelyCached reports whether keyID was recently looked up and
+	// found absent from the JWKS.
+	IsNegativelyCached(keyID string) bool
+
+	// AddNegative records that keyID was absent from the JWKS.
+	AddNegative(keyID string)
+
+	// SetUnknownKidTTL configures how long a negative entry is remembered.
+	// A ttl <= 0 disables negative caching.
+	SetUnknownKidTTL(ttl time.Duration)
+}
+
+type keyCacherEntry struct {
+	addedAt time.Time
+	jose.JSONWebKey
+}
+
+// memoryKeyCacher is an in-memory, process-local KeyCacher.
+type memoryKeyCacher struct {
+	mu      sync.Mutex
+	entries map[string]keyCacherEntry
+	maxAge  time.Duration
+	maxSize int
+
+	// dynamicMaxAge, when non-zero, is snapshotted into entryMaxAge for
+	// entries added while it is set. See SetNextTTL.
+	dynamicMaxAge time.Duration
+
+	// entryMaxAge holds, per kid, the max age captured from dynamicMaxAge at
+	// Add time, so that a later SetNextTTL call doesn't retroactively change
+	// the expiry of entries already cached under a different TTL.
+	entryMaxAge map[string]time.Duration
+
+	// negativeEntries records, for unknownKidTTL, kids that were absent
+	// from the last JWKS download. See NegativeCacher.
+	negativeEntries map[string]time.Time
+	unknownKidTTL   time.Duration
+}
+
+// NewMemoryKeyCacher creates a KeyCacher that keeps keys in memory. A maxAge
+// of MaxAgeNoCheck disables expiration, and a maxSize of -1 disables the
+// entry count limit; a maxSize of 0 disables caching entirely.
+func NewMemoryKeyCacher(maxAge time.Duration, maxSize int) KeyCacher {
+	return &memoryKeyCacher{
+		entries: make(map[string]keyCacherEntry),
+		maxAge:  maxAge,
+		maxSize: maxSize,
+	}
+}
+
+func newMemoryPersistentKeyCacher() KeyCacher {
+	return NewMemoryKeyCacher(MaxAgeNoCheck, -1)
+}
+
+// Get returns the cached key for keyID, or an error if it isn't cached or
+// has expired.
+func (mkc *memoryKeyCacher) Get(keyID string) (*jose.JSONWebKey, error) {
+	mkc.mu.Lock()
+	defer mkc.mu.Unlock()
+
+	searchedEntry, ok := mkc.entries[keyID]
+	if !ok {
+		return nil, ErrNoKeyFound
+	}
+
+	if isExpired(mkc, keyID) {
+		delete(mkc.entries, keyID)
+		delete(mkc.entryMaxAge, keyID)
+		return nil, errors.New("key exists but is expired")
+	}
+
+	return &searchedEntry.JSONWebKey, nil
+}
+
+// Add looks for keyID amongst downloadedKeys and, if found, caches and
+// returns it.
+func (mkc *memoryKeyCacher) Add(keyID string, downloadedKeys []jose.JSONWebKey) (*jose.JSONWebKey, error) {
+	if mkc.maxSize == 0 {
+		return nil, nil
+	}
+
+	var addingKey *jose.JSONWebKey
+	for _, key := range downloadedKeys {
+		if key.KeyID == keyID {
+			k := key
+			addingKey = &k
+			break
+		}
+	}
+
+	if addingKey == nil {
+		return nil, ErrNoKeyFound
+	}
+
+	mkc.mu.Lock()
+	defer mkc.mu.Unlock()
+
+	if mkc.entries == nil {
+		mkc.entries = map[string]keyCacherEntry{}
+	}
+
+	mkc.entries[keyID] = keyCacherEntry{time.Now(), *addingKey}
+
+	if mkc.dynamicMaxAge > 0 {
+		if mkc.entryMaxAge == nil {
+			mkc.entryMaxAge = map[string]time.Duration{}
+		}
+		mkc.entryMaxAge[keyID] = mkc.dynamicMaxAge
+	} else {
+		delete(mkc.entryMaxAge, keyID)
+	}
+
+	handleOverflow(mkc)
+
+	return addingKey, nil
+}
+
+// SetNextTTL implements DynamicTTLKeyCacher.
+func (mkc *memoryKeyCacher) SetNextTTL(ttl time.Duration) {
+	mkc.mu.Lock()
+	defer mkc.mu.Unlock()
+	mkc.dynamicMaxAge = ttl
+}
+
+// IsNegativelyCached implements NegativeCacher.
+func (mkc *memoryKeyCacher) IsNegativelyCached(keyID string) bool {
+	mkc.mu.Lock()
+	defer mkc.mu.Unlock()
+
+	expiresAt, ok := mkc.negativeEntries[keyID]
+	if !ok {
+		return false
+	}
+
+	if time.Now().After(expiresAt) {
+		delete(mkc.negativeEntries, keyID)
+		return false
+	}
+
+	return true
+}
+
+// AddNegative implements NegativeCacher.
+func (mkc *memoryKeyCacher) AddNegative(keyID string) {
+	mkc.mu.Lock()
+	defer mkc.mu.Unlock()
+
+	if mkc.unknownKidTTL <= 0 {
+		return
+	}
+
+	if mkc.negativeEntries == nil {
+		mkc.negativeEntries = map[string]time.Time{}
+	}
+	mkc.negativeEntries[keyID] = time.Now().Add(mkc.unknownKidTTL)
+}
+
+// SetUnknownKidTTL implements NegativeCacher.
+func (mkc *memoryKeyCacher) SetUnknownKidTTL(ttl time.Duration) {
+	mkc.mu.Lock()
+	defer mkc.mu.Unlock()
+	mkc.unknownKidTTL = ttl
+}
+
+// isExpired reports whether the entry cached under keyID has aged past the
+// cacher's effective max age. Callers must hold mkc.mu.
+func isExpired(mkc *memoryKeyCacher, keyID string) bool {
+	maxAge := mkc.maxAge
+	if entryMaxAge, ok := mkc.entryMaxAge[keyID]; ok && entryMaxAge > 0 {
+		maxAge = entryMaxAge
+	}
+
+	if maxAge == MaxAgeNoCheck {
+		return false
+	}
+
+	entry, ok := mkc.entries[keyID]
+	if !ok {
+		return true
+	}
+
+	return time.Now().Sub(entry.addedAt) > maxAge
+}
+
+// handleOverflow evicts the oldest entries until the cache fits within
+// maxSize. Callers must hold mkc.mu. A negative maxSize disables the limit.
+func handleOverflow(mkc *memoryKeyCacher) {
+	if mkc.maxSize < 0 {
+		return
+	}
+
+	for len(mkc.entries) > mkc.maxSize {
+		var oldestKey string
+		var oldestAt time.Time
+		first := true
+
+		for key, entry := range mkc.entries {
+			if first || entry.addedAt.Before(oldestAt) {
+				oldestKey = key
+				oldestAt = entry.addedAt
+				first = false
+			}
+		}
+
+		delete(mkc.entries, oldestKey)
+		delete(mkc.entryMaxAge, oldestKey)
+	}
+}
@@ -427,3 +427,24 @@ func TestHandleOverflow(t *testing.T) {
 		})
 	}
 }
+
+func TestSetNextTTLDoesNotRetroactivelyChangeEarlierEntries(t *testing.T) {
+	downloadedKeys := []jose.JSONWebKey{{KeyID: "test1"}}
+
+	mkc := &memoryKeyCacher{
+		entries: make(map[string]keyCacherEntry),
+		maxAge:  MaxAgeNoCheck,
+		maxSize: -1,
+	}
+
+	mkc.SetNextTTL(time.Hour)
+	_, err := mkc.Add("test1", downloadedKeys)
+	assert.NoError(t, err)
+
+	// A later, shorter TTL applies to newly added entries, not to test1,
+	// which was cached under the hour-long TTL above.
+	mkc.SetNextTTL(time.Nanosecond)
+
+	_, err = mkc.Get("test1")
+	assert.NoError(t, err)
+}
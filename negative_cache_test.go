@@ -0,0 +1,61 @@
+package auth0
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnknownKidIsNegativelyCachedAcrossLookups(t *testing.T) {
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"keys":[{"kid":"key1","kty":"oct","k":"c2VjcmV0"}]}`))
+	}))
+	defer ts.Close()
+
+	client := NewJWKClient(JWKClientOptions{
+		URI:           ts.URL,
+		UnknownKidTTL: time.Hour,
+	}, nil)
+	defer client.Close()
+
+	_, err := client.GetKey("missing")
+	assert.Error(t, err)
+	assert.EqualValues(t, 1, atomic.LoadInt32(&requests))
+
+	_, err = client.GetKey("missing")
+	assert.Error(t, err)
+	assert.EqualValues(t, 1, atomic.LoadInt32(&requests), "a negatively cached kid should not trigger a second download")
+}
+
+func TestUnknownKidNegativeCacheExpires(t *testing.T) {
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"keys":[{"kid":"key1","kty":"oct","k":"c2VjcmV0"}]}`))
+	}))
+	defer ts.Close()
+
+	client := NewJWKClient(JWKClientOptions{
+		URI:           ts.URL,
+		UnknownKidTTL: 10 * time.Millisecond,
+	}, nil)
+	defer client.Close()
+
+	_, err := client.GetKey("missing")
+	assert.Error(t, err)
+	assert.EqualValues(t, 1, atomic.LoadInt32(&requests))
+
+	time.Sleep(20 * time.Millisecond)
+
+	_, err = client.GetKey("missing")
+	assert.Error(t, err)
+	assert.EqualValues(t, 2, atomic.LoadInt32(&requests), "an expired negative cache entry should allow a fresh download")
+}
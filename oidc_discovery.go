@@ -0,0 +1,118 @@
+package auth0
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-errors/errors"
+)
+
+// ErrNoJWKSURI is returned when an OIDC discovery document doesn't advertise
+// a jwks_uri.
+var ErrNoJWKSURI = errors.New("OIDC discovery document has no jwks_uri")
+
+// oidcDiscoveryTTL is how long jwksURI reuses a fetched OIDC discovery
+// document when it isn't forced. The re-discovery that JWKClient.refreshKey
+// runs on a kid-miss always forces a fresh fetch, regardless of this TTL.
+const oidcDiscoveryTTL = 24 * time.Hour
+
+// oidcConfiguration is the subset of the OIDC discovery document
+// (".well-known/openid-configuration") that JWKClient cares about.
+type oidcConfiguration struct {
+	JWKSURI string `json:"jwks_uri"`
+}
+
+// oidcDiscovery fetches and caches the OIDC discovery document for an
+// issuer, re-fetching it on demand when the JWKS it points at no longer has
+// the kid a caller asked for.
+type oidcDiscovery struct {
+	issuer     string
+	httpClient *http.Client
+
+	mu        sync.Mutex
+	fetchedAt time.Time
+	cachedURI string
+}
+
+func newOIDCDiscovery(issuer string) *oidcDiscovery {
+	return &oidcDiscovery{
+		issuer:     strings.TrimRight(issuer, "/"),
+		httpClient: http.DefaultClient,
+	}
+}
+
+// jwksURI fetches the discovery document, honoring oidcDiscoveryTTL unless
+// force is set, and returns the jwks_uri it advertises.
+func (d *oidcDiscovery) jwksURI(force bool) (string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if !force && !d.fetchedAt.IsZero() && time.Since(d.fetchedAt) < oidcDiscoveryTTL {
+		return d.cachedURI, nil
+	}
+
+	resp, err := d.httpClient.Get(d.issuer + "/.well-known/openid-configuration")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var config oidcConfiguration
+	if err := json.NewDecoder(resp.Body).Decode(&config); err != nil {
+		return "", err
+	}
+	if config.JWKSURI == "" {
+		return "", ErrNoJWKSURI
+	}
+
+	d.fetchedAt = time.Now()
+	d.cachedURI = config.JWKSURI
+
+	return d.cachedURI, nil
+}
+
+// refresh re-discovers the issuer's JWKS URI, bypassing the TTL, and points
+// client at it. It is called by JWKClient.refreshKey when a kid isn't found
+// in an otherwise fresh JWKS, in case the IdP rotated its JWKS endpoint.
+func (d *oidcDiscovery) refresh(client *JWKClient) error {
+	uri, err := d.jwksURI(true)
+	if err != nil {
+		return err
+	}
+
+	client.optionsMu.Lock()
+	client.options.URI = uri
+	client.optionsMu.Unlock()
+
+	// The validators we'd been sending were captured from the old JWKS
+	// endpoint; keeping them could make the new endpoint's response to an
+	// unrecognized If-None-Match/If-Modified-Since resolve as a spurious
+	// 304, reusing stale keys.
+	client.validatorMu.Lock()
+	client.etag = ""
+	client.lastModified = ""
+	client.lastKeys = nil
+	client.validatorMu.Unlock()
+
+	return nil
+}
+
+// NewJWKClientFromIssuer creates a JWKClient whose JWKS URI is discovered
+// from the issuer's OIDC discovery document instead of being hardcoded by
+// the caller.
+func NewJWKClientFromIssuer(issuer string, extractor RequestTokenExtractor) (*JWKClient, error) {
+	discovery := newOIDCDiscovery(issuer)
+
+	uri, err := discovery.jwksURI(false)
+	if err != nil {
+		return nil, err
+	}
+
+	client := NewJWKClient(JWKClientOptions{URI: uri}, extractor)
+	client.discovery = discovery
+
+	return client, nil
+}
@@ -0,0 +1,87 @@
+package auth0
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewJWKClientFromIssuerResolvesJWKSURI(t *testing.T) {
+	jwksTS := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"keys":[{"kid":"key1","kty":"oct","k":"c2VjcmV0"}]}`))
+	}))
+	defer jwksTS.Close()
+
+	issuerTS := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/.well-known/openid-configuration", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"jwks_uri":"%s"}`, jwksTS.URL)
+	}))
+	defer issuerTS.Close()
+
+	client, err := NewJWKClientFromIssuer(issuerTS.URL, nil)
+	assert.NoError(t, err)
+	defer client.Close()
+
+	keys, err := client.downloadKeys()
+	assert.NoError(t, err)
+	assert.Len(t, keys, 1)
+}
+
+func TestNewJWKClientFromIssuerNoJWKSURI(t *testing.T) {
+	issuerTS := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{}`))
+	}))
+	defer issuerTS.Close()
+
+	_, err := NewJWKClientFromIssuer(issuerTS.URL, nil)
+	assert.Equal(t, ErrNoJWKSURI, err)
+}
+
+func TestOIDCDiscoveryRefreshRepointsClientAtNewJWKSURI(t *testing.T) {
+	oldJWKSTS := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"keys":[]}`))
+	}))
+	defer oldJWKSTS.Close()
+
+	newJWKSTS := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"keys":[{"kid":"key1","kty":"oct","k":"c2VjcmV0"}]}`))
+	}))
+	defer newJWKSTS.Close()
+
+	var useNewURI int32
+	issuerTS := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		uri := oldJWKSTS.URL
+		if atomic.LoadInt32(&useNewURI) == 1 {
+			uri = newJWKSTS.URL
+		}
+		fmt.Fprintf(w, `{"jwks_uri":"%s"}`, uri)
+	}))
+	defer issuerTS.Close()
+
+	discovery := newOIDCDiscovery(issuerTS.URL)
+	uri, err := discovery.jwksURI(false)
+	assert.NoError(t, err)
+	assert.Equal(t, oldJWKSTS.URL, uri)
+
+	client := NewJWKClient(JWKClientOptions{URI: uri}, nil)
+	defer client.Close()
+	client.discovery = discovery
+
+	atomic.StoreInt32(&useNewURI, 1)
+	assert.NoError(t, discovery.refresh(client))
+	assert.Equal(t, newJWKSTS.URL, client.jwksURI())
+
+	keys, err := client.downloadKeys()
+	assert.NoError(t, err)
+	assert.Len(t, keys, 1)
+}
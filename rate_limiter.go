@@ -0,0 +1,64 @@
+package auth0
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// defaultRefreshBurst is the token bucket capacity used when
+// JWKClientOptions.RefreshBurst is left unset.
+const defaultRefreshBurst = 1
+
+// rateLimiter is a token bucket that gates how often JWKClient will issue a
+// fresh JWKS download in response to kid-miss lookups. It holds up to burst
+// tokens, refilling one token every interval, so a short spike of distinct
+// unknown kids can each trigger a download up to the bucket's capacity
+// before the interval gating kicks in.
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	burst    int
+	tokens   float64
+	last     time.Time
+}
+
+func newRateLimiter(interval time.Duration) *rateLimiter {
+	return newRateLimiterWithBurst(interval, defaultRefreshBurst)
+}
+
+// newRateLimiterWithBurst creates a rateLimiter with the given bucket
+// capacity. A burst < 1 is treated as 1.
+func newRateLimiterWithBurst(interval time.Duration, burst int) *rateLimiter {
+	if burst < 1 {
+		burst = defaultRefreshBurst
+	}
+	return &rateLimiter{interval: interval, burst: burst, tokens: float64(burst)}
+}
+
+// Allow reports whether a download may proceed now, consuming a token if so.
+// An interval <= 0 disables rate limiting entirely.
+func (rl *rateLimiter) Allow() bool {
+	if rl.interval <= 0 {
+		return true
+	}
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := time.Now()
+	if rl.last.IsZero() {
+		rl.last = now
+	} else if elapsed := now.Sub(rl.last); elapsed > 0 {
+		refilled := float64(elapsed) / float64(rl.interval)
+		rl.tokens = math.Min(float64(rl.burst), rl.tokens+refilled)
+		rl.last = now
+	}
+
+	if rl.tokens < 1 {
+		return false
+	}
+
+	rl.tokens--
+	return true
+}
@@ -0,0 +1,59 @@
+package auth0
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiterAllow(t *testing.T) {
+	t.Run("disabled interval always allows", func(t *testing.T) {
+		rl := newRateLimiter(0)
+		assert.True(t, rl.Allow())
+		assert.True(t, rl.Allow())
+		assert.True(t, rl.Allow())
+	})
+
+	t.Run("first call always allows", func(t *testing.T) {
+		rl := newRateLimiter(time.Hour)
+		assert.True(t, rl.Allow())
+	})
+
+	t.Run("second call within the interval is denied", func(t *testing.T) {
+		rl := newRateLimiter(time.Hour)
+		assert.True(t, rl.Allow())
+		assert.False(t, rl.Allow())
+	})
+
+	t.Run("call after the interval has elapsed is allowed again", func(t *testing.T) {
+		rl := newRateLimiter(time.Millisecond)
+		assert.True(t, rl.Allow())
+		time.Sleep(5 * time.Millisecond)
+		assert.True(t, rl.Allow())
+	})
+
+	t.Run("default burst of 1 banks no extra credit for idle intervals", func(t *testing.T) {
+		rl := newRateLimiter(time.Millisecond)
+		assert.True(t, rl.Allow())
+		time.Sleep(10 * time.Millisecond)
+		assert.True(t, rl.Allow())
+		assert.False(t, rl.Allow())
+	})
+
+	t.Run("configured burst allows that many downloads back-to-back", func(t *testing.T) {
+		rl := newRateLimiterWithBurst(time.Hour, 3)
+		assert.True(t, rl.Allow())
+		assert.True(t, rl.Allow())
+		assert.True(t, rl.Allow())
+		assert.False(t, rl.Allow())
+	})
+
+	t.Run("burst is capped and does not grow past its configured capacity", func(t *testing.T) {
+		rl := newRateLimiterWithBurst(time.Millisecond, 2)
+		time.Sleep(20 * time.Millisecond)
+		assert.True(t, rl.Allow())
+		assert.True(t, rl.Allow())
+		assert.False(t, rl.Allow())
+	})
+}
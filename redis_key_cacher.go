@@ -0,0 +1,89 @@
+package auth0
+
+import (
+	"encoding/json"
+	"time"
+
+	"gopkg.in/square/go-jose.v2"
+)
+
+// RedisClient is the subset of a Redis client's API that RedisKeyCacher
+// needs. It lets callers plug in whichever Redis library, cluster, or
+// sentinel wrapper they already use instead of RedisKeyCacher importing one
+// itself.
+type RedisClient interface {
+	Get(key string) (string, error)
+	Set(key string, value string, ttl time.Duration) error
+	Del(key string) error
+}
+
+// RedisKeyCacher is a KeyCacher backed by Redis, so multiple replicas of a
+// service can share JWKS state and TTL instead of each hitting the IdP on
+// its own.
+type RedisKeyCacher struct {
+	client    RedisClient
+	namespace string
+	maxAge    time.Duration
+}
+
+// NewRedisKeyCacher creates a RedisKeyCacher that stores each kid under
+// namespace+":"+kid with a TTL of maxAge. A maxAge of MaxAgeNoCheck stores
+// keys without an expiration.
+func NewRedisKeyCacher(client RedisClient, namespace string, maxAge time.Duration) *RedisKeyCacher {
+	return &RedisKeyCacher{
+		client:    client,
+		namespace: namespace,
+		maxAge:    maxAge,
+	}
+}
+
+func (rkc *RedisKeyCacher) redisKey(keyID string) string {
+	return rkc.namespace + ":" + keyID
+}
+
+// Get implements KeyCacher.
+func (rkc *RedisKeyCacher) Get(keyID string) (*jose.JSONWebKey, error) {
+	data, err := rkc.client.Get(rkc.redisKey(keyID))
+	if err != nil || data == "" {
+		return nil, ErrNoKeyFound
+	}
+
+	var key jose.JSONWebKey
+	if err := json.Unmarshal([]byte(data), &key); err != nil {
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+// Add implements KeyCacher.
+func (rkc *RedisKeyCacher) Add(keyID string, downloadedKeys []jose.JSONWebKey) (*jose.JSONWebKey, error) {
+	var addingKey *jose.JSONWebKey
+	for _, key := range downloadedKeys {
+		if key.KeyID == keyID {
+			k := key
+			addingKey = &k
+			break
+		}
+	}
+
+	if addingKey == nil {
+		return nil, ErrNoKeyFound
+	}
+
+	data, err := json.Marshal(addingKey)
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := rkc.maxAge
+	if ttl == MaxAgeNoCheck {
+		ttl = 0
+	}
+
+	if err := rkc.client.Set(rkc.redisKey(keyID), string(data), ttl); err != nil {
+		return nil, err
+	}
+
+	return addingKey, nil
+}
@@ -0,0 +1,114 @@
+package auth0
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+)
+
+// fakeRedisClient is a minimal in-memory stand-in for a real Redis client,
+// used to exercise RedisKeyCacher without a live server.
+type fakeRedisClient struct {
+	mu        sync.Mutex
+	values    map[string]string
+	expiresAt map[string]time.Time
+}
+
+func newFakeRedisClient() *fakeRedisClient {
+	return &fakeRedisClient{
+		values:    make(map[string]string),
+		expiresAt: make(map[string]time.Time),
+	}
+}
+
+func (c *fakeRedisClient) Get(key string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if expiresAt, ok := c.expiresAt[key]; ok && time.Now().After(expiresAt) {
+		delete(c.values, key)
+		delete(c.expiresAt, key)
+		return "", nil
+	}
+
+	return c.values[key], nil
+}
+
+func (c *fakeRedisClient) Set(key string, value string, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.values[key] = value
+	if ttl > 0 {
+		c.expiresAt[key] = time.Now().Add(ttl)
+	} else {
+		delete(c.expiresAt, key)
+	}
+	return nil
+}
+
+func (c *fakeRedisClient) Del(key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.values, key)
+	delete(c.expiresAt, key)
+	return nil
+}
+
+func TestRedisKeyCacherAddGet(t *testing.T) {
+	downloadedKeys := []jose.JSONWebKey{{KeyID: "test1", Key: []byte("secret1")}, {KeyID: "test2", Key: []byte("secret2")}}
+	rkc := NewRedisKeyCacher(newFakeRedisClient(), "jwks", MaxAgeNoCheck)
+
+	_, err := rkc.Add("test1", downloadedKeys)
+	assert.NoError(t, err)
+
+	key, err := rkc.Get("test1")
+	assert.NoError(t, err)
+	assert.Equal(t, "test1", key.KeyID)
+
+	_, err = rkc.Get("test2")
+	assert.Error(t, err)
+}
+
+func TestRedisKeyCacherTTL(t *testing.T) {
+	downloadedKeys := []jose.JSONWebKey{{KeyID: "test1", Key: []byte("secret1")}}
+	rkc := NewRedisKeyCacher(newFakeRedisClient(), "jwks", 10*time.Millisecond)
+
+	_, err := rkc.Add("test1", downloadedKeys)
+	assert.NoError(t, err)
+
+	_, err = rkc.Get("test1")
+	assert.NoError(t, err)
+
+	time.Sleep(20 * time.Millisecond)
+
+	_, err = rkc.Get("test1")
+	assert.Error(t, err)
+}
+
+func TestRedisKeyCacherConcurrentAddGet(t *testing.T) {
+	downloadedKeys := []jose.JSONWebKey{{KeyID: "test1", Key: []byte("secret1")}}
+	rkc := NewRedisKeyCacher(newFakeRedisClient(), "jwks", MaxAgeNoCheck)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, _ = rkc.Add("test1", downloadedKeys)
+		}()
+		go func() {
+			defer wg.Done()
+			_, _ = rkc.Get("test1")
+		}()
+	}
+	wg.Wait()
+
+	key, err := rkc.Get("test1")
+	assert.NoError(t, err)
+	assert.Equal(t, "test1", key.KeyID)
+}
@@ -0,0 +1,80 @@
+package auth0
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetKeyContextDedupesConcurrentLookupsForSameKid(t *testing.T) {
+	var requests int32
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		<-release
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"keys":[{"kid":"key1","kty":"oct","k":"c2VjcmV0"}]}`))
+	}))
+	defer ts.Close()
+
+	client := NewJWKClient(JWKClientOptions{URI: ts.URL}, nil)
+	defer client.Close()
+
+	const callers = 10
+	var wg sync.WaitGroup
+	wg.Add(callers)
+	for i := 0; i < callers; i++ {
+		go func() {
+			defer wg.Done()
+			key, err := client.GetKey("key1")
+			assert.NoError(t, err)
+			assert.Equal(t, "key1", key.KeyID)
+		}()
+	}
+
+	// Give every goroutine a chance to reach the shared download before it's
+	// allowed to complete, so a bug that issued one request per caller would
+	// be observed here instead of being masked by completing too fast.
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.EqualValues(t, 1, atomic.LoadInt32(&requests), "all concurrent lookups for the same kid should share a single download")
+}
+
+type countingHooks struct {
+	hits, misses, errs int32
+}
+
+func (h *countingHooks) OnCacheHit(keyID string)                { atomic.AddInt32(&h.hits, 1) }
+func (h *countingHooks) OnCacheMiss(keyID string)               { atomic.AddInt32(&h.misses, 1) }
+func (h *countingHooks) OnRefreshError(keyID string, err error) { atomic.AddInt32(&h.errs, 1) }
+
+func TestJWKClientHooksAreNotified(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"keys":[{"kid":"key1","kty":"oct","k":"c2VjcmV0"}]}`))
+	}))
+	defer ts.Close()
+
+	hooks := &countingHooks{}
+	client := NewJWKClient(JWKClientOptions{URI: ts.URL, Hooks: hooks}, nil)
+	defer client.Close()
+
+	_, err := client.GetKey("key1")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, atomic.LoadInt32(&hooks.misses))
+
+	_, err = client.GetKey("key1")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, atomic.LoadInt32(&hooks.hits))
+
+	_, err = client.GetKey("missing")
+	assert.Error(t, err)
+	assert.EqualValues(t, 1, atomic.LoadInt32(&hooks.errs))
+}